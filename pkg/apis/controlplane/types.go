@@ -0,0 +1,80 @@
+// Copyright 2020 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package controlplane holds the wire types the NetworkPolicy controller computes from CRDs and
+// pushes down to the Agents, independent of which CRD (K8s NetworkPolicy, ANP, ACNP) produced them.
+package controlplane
+
+import "k8s.io/apimachinery/pkg/util/intstr"
+
+// Direction of a NetworkPolicy rule.
+type Direction string
+
+const (
+	DirectionIn  Direction = "In"
+	DirectionOut Direction = "Out"
+)
+
+// Protocol defines the network protocol a Service matches on.
+type Protocol string
+
+const (
+	ProtocolTCP  Protocol = "TCP"
+	ProtocolUDP  Protocol = "UDP"
+	ProtocolSCTP Protocol = "SCTP"
+	ProtocolICMP Protocol = "ICMP"
+	ProtocolIGMP Protocol = "IGMP"
+)
+
+// Service describes a port and protocol an AppliedTo entity exposes, or an ICMP/IGMP match.
+type Service struct {
+	Protocol     *Protocol
+	Port         *intstr.IntOrString
+	EndPort      *int32
+	ICMPType     *int32
+	ICMPCode     *int32
+	IGMPType     *int32
+	GroupAddress string
+}
+
+// ServiceReference identifies a K8s Service the NetworkPolicy should apply to or select as a peer.
+type ServiceReference struct {
+	Namespace string
+	Name      string
+}
+
+// IPAddress is the byte representation of an IP address, as produced by net.IP.
+type IPAddress []byte
+
+// IPNet describes a CIDR block.
+type IPNet struct {
+	IP           IPAddress
+	PrefixLength int32
+}
+
+// IPBlock describes a particular CIDR that is allowed or denied as a NetworkPolicyPeer, together
+// with the CIDRs nested within it that are excepted from that rule.
+type IPBlock struct {
+	CIDR   IPNet
+	Except []IPNet
+}
+
+// NetworkPolicyPeer describes the peers (AddressGroups, IPBlocks, FQDNs or Services) a
+// NetworkPolicy rule matches.
+type NetworkPolicyPeer struct {
+	AddressGroups []string
+	IPBlocks      []IPBlock
+	FQDNs         []string
+	ToServices    []ServiceReference
+}
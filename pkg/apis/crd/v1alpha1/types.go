@@ -0,0 +1,145 @@
+// Copyright 2020 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1alpha1 holds the Antrea-native policy CRD types (Tier, ClusterGroup/Group, ANP/ACNP)
+// and the shared building blocks (NetworkPolicyPeer, NetworkPolicyPort, ...) they are built from.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NamespacedName refers to a particular object by Namespace and Name.
+type NamespacedName struct {
+	Namespace string
+	Name      string
+}
+
+// IPBlock describes a particular CIDR that is allowed as a NetworkPolicyPeer.
+type IPBlock struct {
+	CIDR string
+}
+
+// PeerNamespaceMatchType describes how a NetworkPolicyPeer's Namespaces field selects the
+// Namespaces the peer should be expanded into.
+type PeerNamespaceMatchType string
+
+const (
+	// NamespaceMatchSelf selects only the Namespace the policy itself is created in.
+	NamespaceMatchSelf PeerNamespaceMatchType = "Self"
+	// NamespaceMatchExact selects every Namespace sharing the policy's own Namespace's labels
+	// named by SameLabels.
+	NamespaceMatchExact PeerNamespaceMatchType = "SameLabels"
+)
+
+// PeerNamespaces restricts a NetworkPolicyPeer, which would otherwise resolve to a single flat
+// AddressGroup spanning every Namespace it matches, to be expanded into one controlplane peer per
+// Namespace matched by Match (and, for NamespaceMatchExact, sharing labels SameLabels with the
+// policy's own Namespace).
+type PeerNamespaces struct {
+	Match      PeerNamespaceMatchType
+	SameLabels []string
+}
+
+// ClusterSetPeer identifies a peer resolved from a remote member cluster of a Multi-cluster
+// ClusterSet, rather than from this cluster's own Pods/ExternalEntities.
+type ClusterSetPeer struct {
+	// ClusterName is the remote member cluster this peer matches. Empty matches every remote
+	// member cluster of the local ClusterSet.
+	ClusterName string
+	// NamespaceSelector further narrows the peer to Namespaces matching it once ClusterInfo
+	// reports Namespace-scoped PodCIDRs; it is accepted today even though it is not yet applied.
+	NamespaceSelector *metav1.LabelSelector
+}
+
+// NetworkPolicyPeer describes a peer of a NetworkPolicyPeer rule.
+type NetworkPolicyPeer struct {
+	PodSelector            *metav1.LabelSelector
+	NamespaceSelector      *metav1.LabelSelector
+	ExternalEntitySelector *metav1.LabelSelector
+	NodeSelector           *metav1.LabelSelector
+	IPBlock                *IPBlock
+	Group                  string
+	FQDN                   string
+	ServiceAccount         *NamespacedName
+	ClusterSet             *ClusterSetPeer
+	Namespaces             *PeerNamespaces
+}
+
+// ICMPProtocol matches ICMP traffic with the given ICMPType/ICMPCode. A nil field matches any
+// value for that field.
+type ICMPProtocol struct {
+	ICMPType *int32
+	ICMPCode *int32
+}
+
+// IGMPProtocol matches IGMP traffic of the given IGMPType, optionally to a particular multicast
+// GroupAddress.
+type IGMPProtocol struct {
+	IGMPType     *int32
+	GroupAddress string
+}
+
+// NetworkPolicyProtocol matches a non-TCP/UDP/SCTP protocol.
+type NetworkPolicyProtocol struct {
+	ICMP *ICMPProtocol
+	IGMP *IGMPProtocol
+}
+
+// NetworkPolicyPort describes a TCP/UDP/SCTP port and protocol to match.
+type NetworkPolicyPort struct {
+	Protocol *Protocol
+	Port     *intstr.IntOrString
+	EndPort  *int32
+}
+
+// Protocol defines the network protocol a NetworkPolicyPort matches on.
+type Protocol string
+
+const (
+	ProtocolTCP  Protocol = "TCP"
+	ProtocolUDP  Protocol = "UDP"
+	ProtocolSCTP Protocol = "SCTP"
+)
+
+// NetworkPolicyConditionType identifies a particular condition of a NetworkPolicyStatus.
+type NetworkPolicyConditionType string
+
+// NetworkPolicyCondition describes the state of a NetworkPolicy at a certain point.
+type NetworkPolicyCondition struct {
+	Type               NetworkPolicyConditionType
+	Status             metav1.ConditionStatus
+	LastTransitionTime metav1.Time
+}
+
+// NetworkPolicyStatus represents the realization status of a NetworkPolicy across the Nodes that
+// need to implement it.
+type NetworkPolicyStatus struct {
+	Phase              string
+	ObservedGeneration int64
+	Conditions         []NetworkPolicyCondition
+}
+
+// Tier defines a tier of Antrea NetworkPolicies/ClusterNetworkPolicies, used to specify enforcement
+// precedence of multiple ANP/ACNPs.
+type Tier struct {
+	metav1.ObjectMeta
+	Spec TierSpec
+}
+
+// TierSpec defines a Tier's specification.
+type TierSpec struct {
+	Priority int32
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1alpha3 holds the Group/ClusterGroup CRD status types.
+package v1alpha3
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// GroupConditionType identifies a particular condition of a Group/ClusterGroup's status.
+type GroupConditionType string
+
+// GroupMembersComputed indicates whether a Group/ClusterGroup's GroupMembers have been computed
+// and are ready to be read.
+const GroupMembersComputed GroupConditionType = "GroupMembersComputed"
+
+// GroupCondition describes the state of a Group/ClusterGroup at a certain point.
+type GroupCondition struct {
+	Type   GroupConditionType
+	Status metav1.ConditionStatus
+}
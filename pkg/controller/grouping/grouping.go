@@ -0,0 +1,35 @@
+// Copyright 2020 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grouping maintains, for every group (an internal NetworkPolicy Group, or an
+// AppliedToGroup/AddressGroup's own selector) the Pods/ExternalEntities/Nodes it currently
+// resolves to, so the NetworkPolicy controller can recompute group membership without re-listing
+// and re-matching every selector against every cluster member on each event.
+package grouping
+
+// GroupType identifies which kind of controller-internal group a group key belongs to, since
+// Group, AppliedToGroup and AddressGroup keys are not namespaced against one another.
+type GroupType string
+
+// Interface is the grouping cache's API surface.
+type Interface interface {
+	// AddGroup registers or updates the selector a group of groupType and key resolves its
+	// members by.
+	AddGroup(groupType GroupType, key string, selector interface{})
+	// DeleteGroup forgets a group, e.g. once its internal Group/AppliedToGroup/AddressGroup no
+	// longer exists.
+	DeleteGroup(groupType GroupType, key string)
+	// GetGroupMembers returns the current members of a group.
+	GetGroupMembers(groupType GroupType, key string) ([]string, bool)
+}
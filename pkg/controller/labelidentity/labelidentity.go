@@ -0,0 +1,315 @@
+// Copyright 2026 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package labelidentity accelerates selector-to-peer resolution for clusters with a large number
+// of Pods/Namespaces/ExternalEntities. Without it, a single label change on a member has to be
+// checked against every GroupSelector referenced by every policy; this package instead maintains a
+// label -> selector inverted index so a label change only touches the selectors, and therefore the
+// AddressGroups, it could possibly affect.
+package labelidentity
+
+import (
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+// Interface is the label identity index's API surface. The NetworkPolicy controller registers
+// selectors through syncInternalGroup, for namespaced and cluster-scope Groups alike, and looks up
+// label changes through onMemberLabelsUpdate; toAntreaPeerForCRD does not consult it, and the
+// selectors of plain selector peers are not registered with it.
+type Interface interface {
+	// AddLabelIdentity registers that labelIdentity (the canonical, sorted "k=v,..." label
+	// string of a Pod/Namespace/ExternalEntity) is present in the cluster with label identity ID
+	// id, replacing any ID previously recorded for it.
+	AddLabelIdentity(labelIdentity string, id uint32)
+	// DeleteLabelIdentity forgets labelIdentity, e.g. once no member carries it any more.
+	DeleteLabelIdentity(labelIdentity string)
+	// GetLabelIdentityIDs returns the label identity ID assigned to each of labelIdentities that
+	// is currently known; unknown label identities are omitted from the result.
+	GetLabelIdentityIDs(labelIdentities []string) map[string]uint32
+	// SetPolicySelectors registers the GroupSelectors that policyKey currently depends on,
+	// diffing against whatever it depended on before. Selectors no longer referenced by
+	// policyKey are decref'd and, if left with no remaining references, removed from the index.
+	// It returns the identity IDs of every known label identity for which at least one of
+	// selectors is a candidate (see SelectorKeysForLabelIdentity), for the caller to resolve the
+	// initial AddressGroup membership. The selectors are not evaluated, so the result can include
+	// identities that no selector actually matches.
+	SetPolicySelectors(selectors []*antreatypes.GroupSelector, policyKey string) []uint32
+	// DeletePolicySelectors forgets every GroupSelector policyKey referenced, decrementing their
+	// refcounts and removing any selector left with no remaining references.
+	DeletePolicySelectors(policyKey string)
+	// RemoveStalePolicySelectors drops every selector with a zero policy refcount. SetPolicySelectors/
+	// DeletePolicySelectors already do this inline; this exists so a worker can compact the
+	// index explicitly, e.g. after a burst of policy deletes.
+	RemoveStalePolicySelectors()
+	// SelectorKeysForLabelIdentity returns the keys of the selectors that labelIdentity could
+	// match: every selector sharing a label term with it, found via the label -> selector inverted
+	// index rather than by testing every known selector, plus every selector the term index cannot
+	// rule out (see candidateSelectorKeysLocked). The selectors themselves are not evaluated.
+	SelectorKeysForLabelIdentity(labelIdentity string) []string
+}
+
+// index is the default Interface implementation.
+type index struct {
+	mutex sync.RWMutex
+
+	idByIdentity map[string]uint32
+
+	// selectorsByPolicy and policiesBySelector are reverse indices of each other, letting
+	// SetPolicySelectors/DeletePolicySelectors maintain refcounts in either direction.
+	selectorsByPolicy    map[string]map[string]*antreatypes.GroupSelector // policyKey -> selectorKey -> selector
+	policiesBySelector   map[string]map[string]struct{}                   // selectorKey -> policyKeys
+	labelTermToSelectors map[string]map[string]struct{}                   // "k=v" -> selectorKeys whose MatchLabels include it
+	// wildcardSelectors holds every selKey whose selector cannot be fully represented as equality
+	// terms (a match-all selector, or one using MatchExpressions): candidateSelectorKeysLocked must
+	// always treat these as a candidate, since the term index cannot rule them out without risking
+	// a false negative. See requiresWildcard.
+	wildcardSelectors map[string]struct{}
+}
+
+// NewIndex returns an empty label identity index.
+func NewIndex() Interface {
+	return &index{
+		idByIdentity:         map[string]uint32{},
+		selectorsByPolicy:    map[string]map[string]*antreatypes.GroupSelector{},
+		policiesBySelector:   map[string]map[string]struct{}{},
+		labelTermToSelectors: map[string]map[string]struct{}{},
+		wildcardSelectors:    map[string]struct{}{},
+	}
+}
+
+func (i *index) AddLabelIdentity(labelIdentity string, id uint32) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.idByIdentity[labelIdentity] = id
+}
+
+func (i *index) DeleteLabelIdentity(labelIdentity string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	delete(i.idByIdentity, labelIdentity)
+}
+
+func (i *index) GetLabelIdentityIDs(labelIdentities []string) map[string]uint32 {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	ids := make(map[string]uint32, len(labelIdentities))
+	for _, li := range labelIdentities {
+		if id, ok := i.idByIdentity[li]; ok {
+			ids[li] = id
+		}
+	}
+	return ids
+}
+
+func (i *index) SetPolicySelectors(selectors []*antreatypes.GroupSelector, policyKey string) []uint32 {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	newSelectors := make(map[string]*antreatypes.GroupSelector, len(selectors))
+	for _, sel := range selectors {
+		newSelectors[sel.NormalizedName] = sel
+	}
+
+	// Drop references to selectors policyKey no longer depends on.
+	oldSelectors := i.selectorsByPolicy[policyKey]
+	for selKey := range oldSelectors {
+		if _, stillWanted := newSelectors[selKey]; stillWanted {
+			continue
+		}
+		i.decrefSelectorLocked(selKey, policyKey)
+		delete(oldSelectors, selKey)
+	}
+
+	// Add references for selectors policyKey depends on now, indexing newly seen ones.
+	if oldSelectors == nil {
+		oldSelectors = map[string]*antreatypes.GroupSelector{}
+		i.selectorsByPolicy[policyKey] = oldSelectors
+	}
+	for selKey, sel := range newSelectors {
+		if _, existed := oldSelectors[selKey]; !existed {
+			i.indexSelectorLocked(selKey, sel)
+		}
+		oldSelectors[selKey] = sel
+		if i.policiesBySelector[selKey] == nil {
+			i.policiesBySelector[selKey] = map[string]struct{}{}
+		}
+		i.policiesBySelector[selKey][policyKey] = struct{}{}
+	}
+	if len(oldSelectors) == 0 {
+		delete(i.selectorsByPolicy, policyKey)
+	}
+
+	var ids []uint32
+	for identity, id := range i.idByIdentity {
+		for _, selKey := range i.candidateSelectorKeysLocked(identity) {
+			if _, matches := newSelectors[selKey]; matches {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+func (i *index) DeletePolicySelectors(policyKey string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for selKey := range i.selectorsByPolicy[policyKey] {
+		i.decrefSelectorLocked(selKey, policyKey)
+	}
+	delete(i.selectorsByPolicy, policyKey)
+}
+
+func (i *index) RemoveStalePolicySelectors() {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for selKey, policies := range i.policiesBySelector {
+		if len(policies) == 0 {
+			delete(i.policiesBySelector, selKey)
+			i.unindexSelectorLocked(selKey)
+		}
+	}
+}
+
+func (i *index) SelectorKeysForLabelIdentity(labelIdentity string) []string {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.candidateSelectorKeysLocked(labelIdentity)
+}
+
+// decrefSelectorLocked removes policyKey's reference to selKey and, if the selector is left with
+// no remaining references, removes it from the index entirely. The caller must already hold
+// i.mutex and is responsible for removing selKey from policyKey's own selectorsByPolicy entry.
+func (i *index) decrefSelectorLocked(selKey, policyKey string) {
+	policies := i.policiesBySelector[selKey]
+	if policies == nil {
+		return
+	}
+	delete(policies, policyKey)
+	if len(policies) == 0 {
+		delete(i.policiesBySelector, selKey)
+		i.unindexSelectorLocked(selKey)
+	}
+}
+
+func (i *index) indexSelectorLocked(selKey string, sel *antreatypes.GroupSelector) {
+	if requiresWildcard(sel) {
+		i.wildcardSelectors[selKey] = struct{}{}
+		return
+	}
+	for _, term := range labelTerms(sel) {
+		if i.labelTermToSelectors[term] == nil {
+			i.labelTermToSelectors[term] = map[string]struct{}{}
+		}
+		i.labelTermToSelectors[term][selKey] = struct{}{}
+	}
+}
+
+func (i *index) unindexSelectorLocked(selKey string) {
+	delete(i.wildcardSelectors, selKey)
+	for term, selKeys := range i.labelTermToSelectors {
+		delete(selKeys, selKey)
+		if len(selKeys) == 0 {
+			delete(i.labelTermToSelectors, term)
+		}
+	}
+}
+
+// candidateSelectorKeysLocked returns, for a canonical "k=v,..." label identity string, the
+// selector keys that could possibly match it: every selKey in wildcardSelectors (selectors the term
+// index cannot rule out), plus every selKey sharing at least one label term with labelIdentity. This
+// is a narrowing pre-filter, not a full selector evaluation (it ignores set-based operators and
+// NamespaceSelector vs PodSelector provenance), but it is enough to avoid re-running every selector
+// in the cluster on every label change, and it must never omit a selector that could actually match.
+func (i *index) candidateSelectorKeysLocked(labelIdentity string) []string {
+	seen := map[string]struct{}{}
+	for selKey := range i.wildcardSelectors {
+		seen[selKey] = struct{}{}
+	}
+	for _, term := range splitLabelIdentity(labelIdentity) {
+		for selKey := range i.labelTermToSelectors[term] {
+			seen[selKey] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// requiresWildcard reports whether sel has a PodSelector/NamespaceSelector/ExternalEntitySelector
+// that cannot be fully represented as equality terms in labelTermToSelectors: one using
+// MatchExpressions (set-based, not a "k=v" term), or an empty-but-non-nil LabelSelector (matches
+// every member on that axis, i.e. a "match all" selector such as NamespaceSelector{}). Either would
+// make the inverted-index lookup in candidateSelectorKeysLocked return a false negative if sel were
+// indexed by labelTerms alone, so such a selector is instead always returned as a candidate.
+func requiresWildcard(sel *antreatypes.GroupSelector) bool {
+	return selectorNeedsWildcard(sel.PodSelector) ||
+		selectorNeedsWildcard(sel.NamespaceSelector) ||
+		selectorNeedsWildcard(sel.ExternalEntitySelector)
+}
+
+func selectorNeedsWildcard(ls *metav1.LabelSelector) bool {
+	if ls == nil {
+		return false
+	}
+	if len(ls.MatchExpressions) > 0 {
+		return true
+	}
+	return len(ls.MatchLabels) == 0
+}
+
+// labelTerms extracts the "k=v" terms a GroupSelector's PodSelector/NamespaceSelector match
+// exactly, which are the only terms that can be looked up via the inverted index. Only called for a
+// selector that requiresWildcard has already determined is fully representable this way.
+func labelTerms(sel *antreatypes.GroupSelector) []string {
+	var terms []string
+	appendMatchLabels := func(labels map[string]string) {
+		for k, v := range labels {
+			terms = append(terms, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+	if sel.PodSelector != nil {
+		appendMatchLabels(sel.PodSelector.MatchLabels)
+	}
+	if sel.NamespaceSelector != nil {
+		appendMatchLabels(sel.NamespaceSelector.MatchLabels)
+	}
+	if sel.ExternalEntitySelector != nil {
+		appendMatchLabels(sel.ExternalEntitySelector.MatchLabels)
+	}
+	return terms
+}
+
+// splitLabelIdentity parses a canonical, comma-separated "k=v,k2=v2" label identity string back
+// into its individual terms.
+func splitLabelIdentity(labelIdentity string) []string {
+	var terms []string
+	start := 0
+	for idx := 0; idx <= len(labelIdentity); idx++ {
+		if idx == len(labelIdentity) || labelIdentity[idx] == ',' {
+			if idx > start {
+				terms = append(terms, labelIdentity[start:idx])
+			}
+			start = idx + 1
+		}
+	}
+	return terms
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelidentity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+// TestSelectorKeysForLabelIdentityMatchAll verifies that a selector with no usable equality terms
+// (a match-all NamespaceSelector, or one with only MatchExpressions) is still returned as a
+// candidate for every label identity, rather than being silently dropped by the label term index.
+func TestSelectorKeysForLabelIdentityMatchAll(t *testing.T) {
+	idx := NewIndex()
+
+	matchAll := antreatypes.NewGroupSelector("", nil, &metav1.LabelSelector{}, nil, nil)
+	matchExpr := antreatypes.NewGroupSelector("", &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "role", Operator: metav1.LabelSelectorOpExists},
+		},
+	}, nil, nil, nil)
+	exact := antreatypes.NewGroupSelector("", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}, nil, nil, nil)
+
+	idx.SetPolicySelectors([]*antreatypes.GroupSelector{matchAll, matchExpr, exact}, "policyA")
+
+	// A label identity that shares no term with any selector's MatchLabels must still surface the
+	// match-all and MatchExpressions selectors, since they can't be ruled out by the term index.
+	keys := idx.SelectorKeysForLabelIdentity("app=unrelated")
+	assert.ElementsMatch(t, []string{matchAll.NormalizedName, matchExpr.NormalizedName}, keys)
+
+	// A label identity matching the exact selector's term surfaces all three.
+	keys = idx.SelectorKeysForLabelIdentity("app=web")
+	assert.ElementsMatch(t, []string{matchAll.NormalizedName, matchExpr.NormalizedName, exact.NormalizedName}, keys)
+}
+
+// TestSelectorKeysForLabelIdentityExactOnly verifies the term index still narrows correctly for
+// selectors that are fully representable as equality terms: a label identity matching no selector's
+// terms returns none of them.
+func TestSelectorKeysForLabelIdentityExactOnly(t *testing.T) {
+	idx := NewIndex()
+	sel := antreatypes.NewGroupSelector("", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}, nil, nil, nil)
+	idx.SetPolicySelectors([]*antreatypes.GroupSelector{sel}, "policyA")
+
+	assert.Empty(t, idx.SelectorKeysForLabelIdentity("app=other"))
+	assert.Equal(t, []string{sel.NormalizedName}, idx.SelectorKeysForLabelIdentity("app=web"))
+}
+
+// TestDeletePolicySelectorsUnindexesWildcard verifies that removing the last policy referencing a
+// match-all selector also removes it from wildcardSelectors, so it stops being returned as a
+// candidate once nothing depends on it any more.
+func TestDeletePolicySelectorsUnindexesWildcard(t *testing.T) {
+	idx := NewIndex()
+	matchAll := antreatypes.NewGroupSelector("", nil, &metav1.LabelSelector{}, nil, nil)
+	idx.SetPolicySelectors([]*antreatypes.GroupSelector{matchAll}, "policyA")
+	assert.NotEmpty(t, idx.SelectorKeysForLabelIdentity("app=web"))
+
+	idx.DeletePolicySelectors("policyA")
+	assert.Empty(t, idx.SelectorKeysForLabelIdentity("app=web"))
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"sync"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+)
+
+// clusterSetPeerCache maintains, for every remote member cluster of the local ClusterSet, the
+// aggregated PodCIDRs published in its ClusterInfo. It is populated by the multi-cluster
+// controllers' ClusterInfo/ResourceImport handlers and consumed by toAntreaPeerForCRD when
+// resolving a peer.ClusterSet selector.
+type clusterSetPeerCache struct {
+	mutex sync.RWMutex
+	// podCIDRsByCluster holds the latest PodCIDRs reported by each remote member cluster.
+	podCIDRsByCluster map[string][]controlplane.IPNet
+	// policiesByCluster indexes, for each remote cluster name (empty string meaning "all remote
+	// members"), the ACNP/ANP keys whose resolved peer depends on that cluster's PodCIDRs.
+	policiesByCluster map[string]map[string]struct{}
+}
+
+func newClusterSetPeerCache() *clusterSetPeerCache {
+	return &clusterSetPeerCache{
+		podCIDRsByCluster: map[string][]controlplane.IPNet{},
+		policiesByCluster: map[string]map[string]struct{}{},
+	}
+}
+
+// UpdateClusterInfo records the PodCIDRs currently published by a remote member cluster and
+// returns the keys of the policies that referenced this cluster and must be re-enqueued.
+func (c *clusterSetPeerCache) UpdateClusterInfo(clusterName string, podCIDRs []controlplane.IPNet) []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.podCIDRsByCluster[clusterName] = podCIDRs
+	return c.affectedPoliciesLocked(clusterName)
+}
+
+// DeleteClusterInfo removes a remote member cluster, e.g. when it leaves the ClusterSet, and
+// returns the keys of the policies that must be re-enqueued.
+func (c *clusterSetPeerCache) DeleteClusterInfo(clusterName string) []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.podCIDRsByCluster, clusterName)
+	return c.affectedPoliciesLocked(clusterName)
+}
+
+func (c *clusterSetPeerCache) affectedPoliciesLocked(clusterName string) []string {
+	var keys []string
+	// A policy selecting "all remote members" (empty clusterName key) is affected by every
+	// cluster's PodCIDRs change, in addition to any policy that named this cluster specifically.
+	for key := range c.policiesByCluster[clusterName] {
+		keys = append(keys, key)
+	}
+	if clusterName != "" {
+		for key := range c.policiesByCluster[""] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// AddPolicyReference records that policyKey resolved a ClusterSet peer against clusterName (empty
+// meaning "all remote members"), so that a future PodCIDRs update for that cluster re-enqueues it.
+func (c *clusterSetPeerCache) AddPolicyReference(clusterName, policyKey string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.policiesByCluster[clusterName] == nil {
+		c.policiesByCluster[clusterName] = map[string]struct{}{}
+	}
+	c.policiesByCluster[clusterName][policyKey] = struct{}{}
+}
+
+// DeletePolicyReferences removes every cluster reference held by policyKey, e.g. when the policy
+// is deleted or re-synced without a ClusterSet peer.
+func (c *clusterSetPeerCache) DeletePolicyReferences(policyKey string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for clusterName, policies := range c.policiesByCluster {
+		delete(policies, policyKey)
+		if len(policies) == 0 {
+			delete(c.policiesByCluster, clusterName)
+		}
+	}
+}
+
+// podCIDRs returns the PodCIDRs of the named remote member cluster, or of every known remote
+// member cluster when clusterName is empty ("all remote members").
+func (c *clusterSetPeerCache) podCIDRs(clusterName string) []controlplane.IPNet {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if clusterName != "" {
+		return append([]controlplane.IPNet{}, c.podCIDRsByCluster[clusterName]...)
+	}
+	var all []controlplane.IPNet
+	for _, cidrs := range c.podCIDRsByCluster {
+		all = append(all, cidrs...)
+	}
+	return all
+}
+
+// clusterSetPeerIPBlocks resolves a v1alpha1.ClusterSetPeer to the IPBlocks currently known for the
+// named (or, if empty, all) remote member clusters, and records policyKey as a reference so that a
+// later PodCIDRs change for those clusters re-enqueues it. A peer naming a cluster with no known
+// PodCIDRs yet (or a ClusterSet with no remote members) intentionally resolves to zero IPBlocks
+// rather than falling back to matching all addresses.
+func (n *NetworkPolicyController) clusterSetPeerIPBlocks(clusterName, policyKey string) []controlplane.IPNet {
+	n.clusterSetPeerCache.AddPolicyReference(clusterName, policyKey)
+	return n.clusterSetPeerCache.podCIDRs(clusterName)
+}
@@ -0,0 +1,350 @@
+// Copyright 2020 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	"antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	"antrea.io/antrea/pkg/controller/grouping"
+	"antrea.io/antrea/pkg/controller/labelidentity"
+	"antrea.io/antrea/pkg/controller/networkpolicy/store"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+// DefaultTierPriority is the priority assigned to the lowest, built-in Application Tier, used
+// whenever an ANP/ACNP does not name a Tier of its own.
+const DefaultTierPriority int32 = 250
+
+// staticTierSet names the Tiers that existed as hardcoded priorities before Tier became a CRD in
+// 0.10.0; getTierPriority lowercases a matching name so policies created against an old static
+// tier keep resolving to the same Tier CRD after upgrade.
+var staticTierSet = sets.NewString("Emergency", "SecurityOps", "NetworkOps", "Platform", "Application")
+
+// internalGroupType is the grouping cache type under which internal Group objects are tracked. The
+// selectors of AppliedToGroups/AddressGroups that carry their own Selector are registered under the
+// same type (see commitAppliedToGroup/commitAddressGroup).
+const internalGroupType grouping.GroupType = "internalGroup"
+
+// matchAllPeer is the controlplane.NetworkPolicyPeer used for a rule with no peers and no
+// ClusterGroup, which by convention matches every address.
+var matchAllPeer = controlplane.NetworkPolicyPeer{
+	IPBlocks: []controlplane.IPBlock{
+		{
+			CIDR: controlplane.IPNet{IP: controlplane.IPAddress(net.IPv4zero.To4()), PrefixLength: 0},
+		},
+	},
+}
+
+// TierLister looks up a Tier CRD by name.
+type TierLister interface {
+	Get(name string) (*v1alpha1.Tier, error)
+}
+
+// NamespaceLister looks up Namespace objects and their labels, needed to resolve a
+// NamespaceMatchExact ("SameLabels") peer to the set of Namespaces sharing the policy's own
+// Namespace's values for the label keys it names.
+type NamespaceLister interface {
+	Get(name string) (*corev1.Namespace, error)
+	List(selector labels.Selector) ([]*corev1.Namespace, error)
+}
+
+// InternalNetworkPolicyRecomputer fully recomputes one internal NetworkPolicy's AppliedToGroup/
+// AddressGroup specs, i.e. the same compute step its own Add/Update handler runs, and stages the
+// result via EnqueuePolicyGroups. It is implemented by whatever owns the NetworkPolicy/ANP/ACNP
+// informers and converts them to the controlplane representation; this controller only tracks which
+// policies a ClusterSet peer affects (via clusterSetPeerCache), it cannot recompute one from scratch
+// on its own.
+type InternalNetworkPolicyRecomputer interface {
+	RecomputeInternalNetworkPolicy(policyKey string)
+}
+
+// NetworkPolicyController computes the controlplane representation of K8s NetworkPolicies, Antrea
+// ClusterGroups/Groups and ANPs/ACNPs, and serves it to the Agents.
+type NetworkPolicyController struct {
+	// internalGroupStore holds the internal Group objects computed for every ClusterGroup/Group.
+	internalGroupStore store.Interface
+	// appliedToGroupStore and addressGroupStore hold the AppliedToGroup/AddressGroup objects
+	// derived from internalGroupStore, Services, ServiceAccounts and plain selector peers, and
+	// served to the Agents.
+	appliedToGroupStore store.Interface
+	addressGroupStore   store.Interface
+
+	// groupingInterface tracks which Pods/ExternalEntities/Nodes currently match each internal
+	// Group's, AppliedToGroup's or AddressGroup's selector.
+	groupingInterface grouping.Interface
+
+	// clusterSetPeerCache aggregates PodCIDRs reported by remote member clusters of the local
+	// Multi-cluster ClusterSet, consumed when resolving a peer.ClusterSet peer.
+	clusterSetPeerCache *clusterSetPeerCache
+
+	// tierLister looks up a Tier CRD's priority.
+	tierLister TierLister
+
+	// namespaceLister looks up Namespace objects and their labels, used to resolve a
+	// NamespaceMatchExact ("SameLabels") peer.
+	namespaceLister NamespaceLister
+
+	// recomputer triggers a full recompute of an internal NetworkPolicy, e.g. when a ClusterSet
+	// peer it depends on changes. It is wired up after construction, via
+	// SetInternalNetworkPolicyRecomputer, by whoever owns the NetworkPolicy/ANP/ACNP informers.
+	recomputer InternalNetworkPolicyRecomputer
+
+	// labelIdentityIndex accelerates resolving a Pod/Namespace/ExternalEntity label change to the
+	// GroupSelectors, and therefore AddressGroups, it could affect. labelIdentityIDs assigns the
+	// uint32 ids it records.
+	labelIdentityIndex labelidentity.Interface
+	labelIdentityIDs   *labelIdentityAllocator
+
+	// queue holds the keys of internal NetworkPolicies whose AppliedToGroup/AddressGroup specs
+	// have been computed and are waiting for their worker to commit.
+	queue workqueue.RateLimitingInterface
+	// policyLocks serializes commitPolicyGroups for a given policy key against itself (e.g. an
+	// Update racing a Delete), without making unrelated policies' workers block on one another.
+	policyLocks *keyMutex
+	// groupRefCounter tracks which policies reference which AppliedToGroup/AddressGroup, so a
+	// group is only ever deleted once no policy's most recently committed recompute needs it.
+	groupRefCounter *groupRefCounter
+
+	// pendingSpecsMutex guards pendingSpecs.
+	pendingSpecsMutex sync.Mutex
+	// pendingSpecs holds, per policy key, the AppliedToGroup/AddressGroup specs computed by that
+	// policy's Add/Update handler and not yet committed by its worker.
+	pendingSpecs map[string]*policyGroupSpecs
+}
+
+// NewNetworkPolicyController returns a NetworkPolicyController with empty stores, ready to have its
+// event handlers registered against the relevant informers.
+func NewNetworkPolicyController(tierLister TierLister, namespaceLister NamespaceLister, groupingInterface grouping.Interface) *NetworkPolicyController {
+	return &NetworkPolicyController{
+		internalGroupStore:  store.NewGroupStore(),
+		appliedToGroupStore: store.NewAppliedToGroupStore(),
+		addressGroupStore:   store.NewAddressGroupStore(),
+		groupingInterface:   groupingInterface,
+		clusterSetPeerCache: newClusterSetPeerCache(),
+		tierLister:          tierLister,
+		namespaceLister:     namespaceLister,
+		labelIdentityIndex:  labelidentity.NewIndex(),
+		labelIdentityIDs:    newLabelIdentityAllocator(),
+		queue:               workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "internalNetworkPolicy"),
+		policyLocks:         newKeyMutex(),
+		groupRefCounter:     newGroupRefCounter(),
+		pendingSpecs:        map[string]*policyGroupSpecs{},
+	}
+}
+
+// SetInternalNetworkPolicyRecomputer wires the callback enqueueInternalNetworkPolicy uses to trigger
+// a full recompute of an internal NetworkPolicy. It must be called once during startup, before Run,
+// by the controller that owns the NetworkPolicy/ANP/ACNP informers, or a ClusterSet peer's PodCIDR
+// change will be recorded in clusterSetPeerCache but never actually reflected in any policy.
+func (n *NetworkPolicyController) SetInternalNetworkPolicyRecomputer(r InternalNetworkPolicyRecomputer) {
+	n.recomputer = r
+}
+
+// getNormalizedUID returns the UID Antrea assigns an AppliedToGroup/AddressGroup/internal Group
+// object derived from name, so that the same logical group (e.g. the same selector combination, or
+// the same Service) always resolves to the same UID regardless of which policy first created it.
+func getNormalizedUID(name string) string {
+	return string(types.UID(name))
+}
+
+// cidrStrToIPNet converts a CIDR string (e.g. "10.0.0.0/8") to a controlplane.IPNet.
+func cidrStrToIPNet(cidr string) (*controlplane.IPNet, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %s: %v", cidr, err)
+	}
+	prefixLength, _ := ipNet.Mask.Size()
+	return &controlplane.IPNet{
+		IP:           controlplane.IPAddress(ipNet.IP),
+		PrefixLength: int32(prefixLength),
+	}, nil
+}
+
+// toAntreaProtocol converts a v1alpha1.Protocol to a controlplane.Protocol, defaulting to TCP when
+// unset, matching the K8s NetworkPolicy convention for an empty Protocol field.
+func toAntreaProtocol(npProtocol *v1alpha1.Protocol) *controlplane.Protocol {
+	protocol := controlplane.ProtocolTCP
+	if npProtocol != nil {
+		protocol = controlplane.Protocol(*npProtocol)
+	}
+	return &protocol
+}
+
+// serviceAccountPodLabelKey is the label Antrea's agent stamps onto every Pod with the name of its
+// spec.ServiceAccountName, so a ServiceAccount AppliedTo/peer can be resolved through the same
+// selector-based machinery as any other label. Kubernetes itself never labels a Pod with its
+// ServiceAccount: "kubernetes.io/service-account.name" only ever appears as an annotation on the
+// ServiceAccount token Secret, never as a Pod label. An ordinary "kubernetes.io/..." key would also
+// not be reserved against user Pods, so it could be spoofed; an antrea.io-prefixed key that only
+// Antrea's own components ever write is what makes it trustworthy as a selector target.
+const serviceAccountPodLabelKey = "antrea.io/service-account-name"
+
+// serviceAccountNameToPodSelector returns the PodSelector matching every Pod whose ServiceAccount is
+// saName, via the reserved serviceAccountPodLabelKey label.
+func serviceAccountNameToPodSelector(saName string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			serviceAccountPodLabelKey: saName,
+		},
+	}
+}
+
+// triggerParentGroupSync re-enqueues every AppliedToGroup/AddressGroup derived from the internal
+// Group keyed groupKey, found via store.SourceGroupIndex, since a single internal Group can back
+// more than one derived group (e.g. one per Namespace). This is the only mechanism this controller
+// has for reacting to a Group/ClusterGroup change: it has no ANP-vs-ACNP-specific index of its own
+// (see controlplane.NetworkPolicy, which this trimmed-down tree does not define), so both an ANP's
+// and an ACNP's Group/ClusterGroup reference are reached the same way, through whichever derived
+// AppliedToGroup/AddressGroup they ended up committing.
+func (n *NetworkPolicyController) triggerParentGroupSync(groupKey string) {
+	appliedToGroups, _ := n.appliedToGroupStore.ByIndex(store.SourceGroupIndex, groupKey)
+	for _, obj := range appliedToGroups {
+		n.enqueueAppliedToGroup(obj.(*antreatypes.AppliedToGroup).Name)
+	}
+	addressGroups, _ := n.addressGroupStore.ByIndex(store.SourceGroupIndex, groupKey)
+	for _, obj := range addressGroups {
+		n.enqueueAddressGroup(obj.(*antreatypes.AddressGroup).Name)
+	}
+}
+
+// enqueueAppliedToGroup re-enqueues, via groupRefCounter, every policy currently referencing the
+// AppliedToGroup keyed key, e.g. after it is first created or its membership changes.
+func (n *NetworkPolicyController) enqueueAppliedToGroup(key string) {
+	for _, policyKey := range n.groupRefCounter.PoliciesReferencing(key) {
+		n.enqueueInternalNetworkPolicy(policyKey)
+	}
+}
+
+// enqueueAddressGroup re-enqueues, via groupRefCounter, every policy currently referencing the
+// AddressGroup keyed key.
+func (n *NetworkPolicyController) enqueueAddressGroup(key string) {
+	for _, policyKey := range n.groupRefCounter.PoliciesReferencing(key) {
+		n.enqueueInternalNetworkPolicy(policyKey)
+	}
+}
+
+// enqueueInternalNetworkPolicy triggers a full recompute of the internal NetworkPolicy keyed
+// policyKey, e.g. because a Group, Service or ClusterSet peer it depends on changed. This cannot
+// simply re-add policyKey to n.queue: that queue's worker only ever commits whatever is already
+// staged in pendingSpecs (see EnqueuePolicyGroups), and nothing has re-staged fresh specs here, so it
+// must instead go through recomputer, which has access to the original NetworkPolicy/ANP/ACNP object
+// and ends by calling EnqueuePolicyGroups itself once it has recomputed.
+func (n *NetworkPolicyController) enqueueInternalNetworkPolicy(policyKey string) {
+	if n.recomputer == nil {
+		klog.V(2).InfoS("No InternalNetworkPolicyRecomputer wired up yet; dropping recompute trigger", "policy", policyKey)
+		return
+	}
+	n.recomputer.RecomputeInternalNetworkPolicy(policyKey)
+}
+
+// onClusterInfoUpdate handles a ClusterInfo create/update event reported for a remote member
+// cluster of the local ClusterSet, recording its PodCIDRs and re-enqueuing every ANP/ACNP whose
+// resolved peer depends on it. It is registered against the Multi-cluster controller's ClusterInfo
+// informer alongside this controller's own NetworkPolicy/ClusterGroup/Group handlers.
+func (n *NetworkPolicyController) onClusterInfoUpdate(clusterName string, podCIDRs []controlplane.IPNet) {
+	for _, policyKey := range n.clusterSetPeerCache.UpdateClusterInfo(clusterName, podCIDRs) {
+		n.enqueueInternalNetworkPolicy(policyKey)
+	}
+}
+
+// onClusterInfoDelete handles a ClusterInfo delete event, e.g. a remote member cluster leaving the
+// ClusterSet, forgetting its PodCIDRs and re-enqueuing every policy that depended on it.
+func (n *NetworkPolicyController) onClusterInfoDelete(clusterName string) {
+	for _, policyKey := range n.clusterSetPeerCache.DeleteClusterInfo(clusterName) {
+		n.enqueueInternalNetworkPolicy(policyKey)
+	}
+}
+
+// matchedNamespacesForPeer returns the Namespaces peerNamespaces matches. NamespaceMatchSelf (the
+// default) resolves trivially to the policy's own Namespace. NamespaceMatchExact resolves to every
+// Namespace that shares defaultNamespace's values for the label keys named by
+// peerNamespaces.SameLabels (not a full label-selector match), via namespaceLister.
+func (n *NetworkPolicyController) matchedNamespacesForPeer(peerNamespaces *v1alpha1.PeerNamespaces, defaultNamespace string) []string {
+	if peerNamespaces.Match != v1alpha1.NamespaceMatchExact {
+		return []string{defaultNamespace}
+	}
+	selfNamespace, err := n.namespaceLister.Get(defaultNamespace)
+	if err != nil {
+		klog.ErrorS(err, "Failed to get own Namespace for SameLabels peer resolution", "namespace", defaultNamespace)
+		return nil
+	}
+	allNamespaces, err := n.namespaceLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list Namespaces for SameLabels peer resolution")
+		return nil
+	}
+	var matched []string
+	for _, ns := range allNamespaces {
+		if namespaceSharesLabels(selfNamespace.Labels, ns.Labels, peerNamespaces.SameLabels) {
+			matched = append(matched, ns.Name)
+		}
+	}
+	return matched
+}
+
+// namespaceSharesLabels reports whether otherLabels has the same value as selfLabels for every key
+// named in keys, the matching rule for a NamespaceMatchExact ("SameLabels") peer.
+func namespaceSharesLabels(selfLabels, otherLabels map[string]string, keys []string) bool {
+	for _, key := range keys {
+		value, ok := selfLabels[key]
+		if !ok || otherLabels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// syncInternalNamespacedGroup is the Namespace-scoped Group branch of syncInternalGroup. The work
+// common to both branches (registering grp's Selector with labelIdentityIndex, then re-enqueuing
+// every policy referencing a derived AppliedToGroup/AddressGroup via the deferred
+// triggerParentGroupSync) already happens in syncInternalGroup; a namespaced Group never nests
+// ChildGroups the way a ClusterGroup can, so there is nothing further to recompute here today. The
+// branch is kept as the seam for that Namespace-scoped-only logic once it exists, rather than
+// collapsing the two into one function.
+func (n *NetworkPolicyController) syncInternalNamespacedGroup(grp *antreatypes.Group) error {
+	return nil
+}
+
+// syncInternalClusterGroup is the cluster-scoped ClusterGroup branch of syncInternalGroup. As with
+// syncInternalNamespacedGroup, the common work happens in syncInternalGroup; resolving a
+// ClusterGroup's ChildGroups into the union of their members would belong here, but is not
+// implemented in this tree.
+func (n *NetworkPolicyController) syncInternalClusterGroup(grp *antreatypes.Group) error {
+	return nil
+}
+
+// Run starts workerCount per-policy workers and blocks until stopCh is closed. Each worker only
+// ever commits groups for one policy key at a time (commitPolicyGroups serializes further via
+// policyLocks), so workerCount bounds how many distinct policies can be recomputed concurrently,
+// not how many times the same policy's commit can run at once.
+func (n *NetworkPolicyController) Run(workerCount int, stopCh <-chan struct{}) {
+	defer n.queue.ShutDown()
+	for i := 0; i < workerCount; i++ {
+		go n.policyWorker()
+	}
+	<-stopCh
+}
@@ -15,6 +15,7 @@
 package networkpolicy
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -127,32 +128,41 @@ func toAntreaIPBlockForCRD(ipBlock *v1alpha1.IPBlock) (*controlplane.IPBlock, er
 // toAntreaPeerForCRD creates a Antrea controlplane NetworkPolicyPeer for crdv1alpha1 NetworkPolicyPeer.
 // It is used when peer's Namespaces are not matched by NamespaceMatchTypes, for which the controlplane
 // NetworkPolicyPeers will need to be created on a per Namespace basis.
+// The exception is a Group/ClusterGroup peer that also carries Namespaces: this function expands
+// that peer per matching Namespace itself, via toNamespacedGroupPeerForCRD.
+//
+// Like the compute* helpers below, this only reads internalGroupStore and never touches
+// addressGroupStore; it is meant to be called from within a policy's Add/Update handler, which must
+// append the returned addressGroupSpecs to the rest of the policy's pending specs and stage them all
+// via EnqueuePolicyGroups, so its own worker commits them atomically with the rest of the recomputed
+// internal NetworkPolicy instead of a concurrent delete of the Group/ClusterGroup they were resolved
+// from ever being able to race ahead of this policy's commit.
 func (n *NetworkPolicyController) toAntreaPeerForCRD(peers []v1alpha1.NetworkPolicyPeer,
-	np metav1.Object, dir controlplane.Direction, namedPortExists bool) *controlplane.NetworkPolicyPeer {
+	np metav1.Object, dir controlplane.Direction, namedPortExists bool) (*controlplane.NetworkPolicyPeer, []*addressGroupSpec) {
 	var addressGroups []string
+	var pendingSpecs []*addressGroupSpec
 	// NetworkPolicyPeer is supposed to match all addresses when it is empty and no clusterGroup is present.
 	// It's treated as an IPBlock "0.0.0.0/0".
 	if len(peers) == 0 {
-		// For an egress Peer that specifies any named ports, it creates or
-		// reuses the AddressGroup matching all Pods in all Namespaces and
-		// appends the AddressGroup UID to the returned Peer such that it can be
-		// used to resolve the named ports.
+		// For an egress Peer that specifies any named ports, it resolves to the AddressGroup
+		// matching all Pods in all Namespaces and appends its key to the returned Peer such
+		// that it can be used to resolve the named ports.
 		// For other cases it uses the IPBlock "0.0.0.0/0" to avoid the overhead
 		// of handling member updates of the AddressGroup.
 		if dir == controlplane.DirectionIn || !namedPortExists {
-			return &matchAllPeer
+			return &matchAllPeer, nil
 		}
-		allPodsGroupUID := n.createAddressGroup("", matchAllPodsPeerCrd.PodSelector, matchAllPodsPeerCrd.NamespaceSelector, nil, nil)
+		allPodsSpec := computeAddressGroupForSelector("", matchAllPodsPeerCrd.PodSelector, matchAllPodsPeerCrd.NamespaceSelector, nil, nil)
 		podsPeer := matchAllPeer
-		podsPeer.AddressGroups = append(addressGroups, allPodsGroupUID)
-		return &podsPeer
+		podsPeer.AddressGroups = append(addressGroups, allPodsSpec.key)
+		return &podsPeer, []*addressGroupSpec{allPodsSpec}
 	}
 	var ipBlocks []controlplane.IPBlock
 	var fqdns []string
 	for _, peer := range peers {
 		// A v1alpha1.NetworkPolicyPeer will either have an IPBlock or FQDNs or a
 		// podSelector and/or namespaceSelector set or a reference to the
-		// ClusterGroup.
+		// ClusterGroup or a ClusterSet.
 		if peer.IPBlock != nil {
 			ipBlock, err := toAntreaIPBlockForCRD(peer.IPBlock)
 			if err != nil {
@@ -161,7 +171,26 @@ func (n *NetworkPolicyController) toAntreaPeerForCRD(peers []v1alpha1.NetworkPol
 			}
 			ipBlocks = append(ipBlocks, *ipBlock)
 		} else if peer.Group != "" {
-			normalizedUID, groupIPBlocks := n.processRefGroupOrClusterGroup(peer.Group, np.GetNamespace())
+			if peer.Namespaces != nil {
+				// A Group/ClusterGroup peer combined with Namespaces expands into one
+				// controlplane peer per matching Namespace, resolved by toNamespacedGroupPeerForCRD,
+				// rather than into a single flat AddressGroup spanning every Namespace the Group
+				// selects.
+				for _, namespace := range n.matchedNamespacesForPeer(peer.Namespaces, np.GetNamespace()) {
+					nsPeer, nsSpecs, err := n.toNamespacedGroupPeerForCRD(peer.Group, np.GetNamespace(), namespace)
+					if err != nil {
+						klog.Errorf("Antrea NetworkPolicy %s/%s: %v", np.GetNamespace(), np.GetName(), err)
+						continue
+					}
+					addressGroups = append(addressGroups, nsPeer.AddressGroups...)
+					pendingSpecs = append(pendingSpecs, nsSpecs...)
+				}
+				continue
+			}
+			normalizedUID, groupIPBlocks, addrSpec := n.processRefGroupOrClusterGroup(peer.Group, np.GetNamespace())
+			if addrSpec != nil {
+				pendingSpecs = append(pendingSpecs, addrSpec)
+			}
 			if normalizedUID != "" {
 				addressGroups = append(addressGroups, normalizedUID)
 			}
@@ -169,29 +198,97 @@ func (n *NetworkPolicyController) toAntreaPeerForCRD(peers []v1alpha1.NetworkPol
 		} else if peer.FQDN != "" {
 			fqdns = append(fqdns, peer.FQDN)
 		} else if peer.ServiceAccount != nil {
-			normalizedUID := n.createAddressGroup(peer.ServiceAccount.Namespace, serviceAccountNameToPodSelector(peer.ServiceAccount.Name), nil, nil, nil)
-			addressGroups = append(addressGroups, normalizedUID)
+			saSpec := computeAddressGroupForSelector(peer.ServiceAccount.Namespace, serviceAccountNameToPodSelector(peer.ServiceAccount.Name), nil, nil, nil)
+			addressGroups = append(addressGroups, saSpec.key)
+			pendingSpecs = append(pendingSpecs, saSpec)
 		} else if peer.NodeSelector != nil {
-			normalizedUID := n.createAddressGroup("", nil, nil, nil, peer.NodeSelector)
-			addressGroups = append(addressGroups, normalizedUID)
+			nodeSpec := computeAddressGroupForSelector("", nil, nil, nil, peer.NodeSelector)
+			addressGroups = append(addressGroups, nodeSpec.key)
+			pendingSpecs = append(pendingSpecs, nodeSpec)
+		} else if peer.ClusterSet != nil {
+			// peer.ClusterSet names a remote member cluster (or, if empty, all remote members)
+			// of the local ClusterSet. It resolves to the PodCIDRs aggregated from that
+			// cluster's ClusterInfo rather than to an AddressGroup, since remote Pods are not
+			// watched directly. A ClusterSet peer that currently has no known PodCIDRs (e.g. the
+			// named cluster has not reported yet) deliberately contributes zero IPBlocks instead
+			// of falling back to the "match all" behavior used for a wholly empty peers slice.
+			// peer.ClusterSet.NamespaceSelector is accepted so that a future ClusterInfo
+			// carrying namespace-scoped PodCIDRs can narrow the expansion to matching
+			// Namespaces; today's ClusterInfo only reports cluster-wide PodCIDRs, so the
+			// selector is not yet applied.
+			policyKey := k8s.NamespacedName(np.GetNamespace(), np.GetName())
+			if peer.ClusterSet.NamespaceSelector != nil {
+				klog.V(4).InfoS("ClusterSet peer NamespaceSelector is accepted but not yet applied; ClusterInfo only reports cluster-wide PodCIDRs today", "policy", policyKey)
+			}
+			for _, cidr := range n.clusterSetPeerIPBlocks(peer.ClusterSet.ClusterName, policyKey) {
+				ipBlocks = append(ipBlocks, controlplane.IPBlock{CIDR: cidr, Except: []controlplane.IPNet{}})
+			}
 		} else {
-			normalizedUID := n.createAddressGroup(np.GetNamespace(), peer.PodSelector, peer.NamespaceSelector, peer.ExternalEntitySelector, nil)
-			addressGroups = append(addressGroups, normalizedUID)
+			selSpec := computeAddressGroupForSelector(np.GetNamespace(), peer.PodSelector, peer.NamespaceSelector, peer.ExternalEntitySelector, nil)
+			addressGroups = append(addressGroups, selSpec.key)
+			pendingSpecs = append(pendingSpecs, selSpec)
 		}
 	}
-	return &controlplane.NetworkPolicyPeer{AddressGroups: addressGroups, IPBlocks: ipBlocks, FQDNs: fqdns}
+	return &controlplane.NetworkPolicyPeer{AddressGroups: addressGroups, IPBlocks: ipBlocks, FQDNs: fqdns}, pendingSpecs
 }
 
 // toNamespacedPeerForCRD creates an Antrea controlplane NetworkPolicyPeer for crdv1alpha1 NetworkPolicyPeer
 // for a particular Namespace. It is used when a single crdv1alpha1 NetworkPolicyPeer maps to multiple
 // controlplane NetworkPolicyPeers because the appliedTo workloads reside in different Namespaces.
-func (n *NetworkPolicyController) toNamespacedPeerForCRD(peers []v1alpha1.NetworkPolicyPeer, namespace string) *controlplane.NetworkPolicyPeer {
+// Like toAntreaPeerForCRD, it only computes; the caller must stage the returned addressGroupSpecs via
+// EnqueuePolicyGroups for its worker to commit.
+func (n *NetworkPolicyController) toNamespacedPeerForCRD(peers []v1alpha1.NetworkPolicyPeer, namespace string) (*controlplane.NetworkPolicyPeer, []*addressGroupSpec) {
 	var addressGroups []string
+	var specs []*addressGroupSpec
 	for _, peer := range peers {
-		normalizedUID := n.createAddressGroup(namespace, peer.PodSelector, nil, peer.ExternalEntitySelector, nil)
-		addressGroups = append(addressGroups, normalizedUID)
+		spec := computeAddressGroupForSelector(namespace, peer.PodSelector, nil, peer.ExternalEntitySelector, nil)
+		addressGroups = append(addressGroups, spec.key)
+		specs = append(specs, spec)
+	}
+	return &controlplane.NetworkPolicyPeer{AddressGroups: addressGroups}, specs
+}
+
+// toNamespacedGroupPeerForCRD creates an Antrea controlplane NetworkPolicyPeer for a single
+// crdv1alpha1 NetworkPolicyPeer that references a Group/ClusterGroup and also carries a Namespaces
+// field (Self, or match-by-label), scoped to one Namespace matching it. It is called once per
+// Namespace matched by peer.Namespaces, analogous to how toNamespacedPeerForCRD is called once per
+// Namespace for the appliedTo case, so that the Group's members end up as one controlplane peer
+// per matching Namespace instead of a single flat AddressGroup spanning every Namespace the Group
+// selects. groupName is resolved in policyNamespace, the same as for a Group peer without
+// Namespaces; namespace only scopes the resulting AddressGroup. A change to the Group's members
+// re-enqueues the policy through the returned AddressGroup's SourceGroupName (see
+// triggerParentGroupSync). A change to which Namespaces match peer.Namespaces does not re-enqueue
+// the policy from this controller; the result is only recomputed on the policy's next recompute.
+//
+// It rejects groupName resolving to an IPBlock- or ChildGroup-only Group, since those have no
+// per-Namespace Pod membership to intersect with; in the full validating webhook this same
+// rejection happens earlier, at admission time, but the conversion path re-checks it defensively. The
+// flat, all-Namespace addressGroupSpec processRefGroupOrClusterGroup would derive for groupName is
+// deliberately discarded here: only the per-Namespace AddressGroup actually used by the returned peer
+// needs to be staged, or the flat one would leak as a group no policy's groupKeys ever references.
+func (n *NetworkPolicyController) toNamespacedGroupPeerForCRD(groupName, policyNamespace, namespace string) (*controlplane.NetworkPolicyPeer, []*addressGroupSpec, error) {
+	normalizedUID, groupIPBlocks, _ := n.processRefGroupOrClusterGroup(groupName, policyNamespace)
+	if len(groupIPBlocks) > 0 {
+		return nil, nil, fmt.Errorf("Group %s cannot be combined with Namespaces: it resolves to IPBlocks rather than Pod members", groupName)
+	}
+	if normalizedUID == "" {
+		return nil, nil, fmt.Errorf("Group %s cannot be combined with Namespaces: its internal Group was not found", groupName)
+	}
+	grpObj, found, _ := n.internalGroupStore.Get(normalizedUID)
+	if !found {
+		return nil, nil, fmt.Errorf("internal Group %s not found", normalizedUID)
+	}
+	grp := grpObj.(*antreatypes.Group)
+	if grp.Selector == nil {
+		return nil, nil, fmt.Errorf("Group %s cannot be combined with Namespaces: it resolves to child Groups rather than Pod members", groupName)
 	}
-	return &controlplane.NetworkPolicyPeer{AddressGroups: addressGroups}
+	nsSpec := computeAddressGroupForSelector(namespace, grp.Selector.PodSelector, nil, grp.Selector.ExternalEntitySelector, nil)
+	// Unlike a plain selector peer, this AddressGroup is derived from the source Group normalizedUID
+	// resolves to, not an independent selector of its own; stamping SourceGroupName makes it
+	// reachable via store.SourceGroupIndex, so triggerParentGroupSync finds and re-enqueues it (and,
+	// transitively, every policy referencing it) when the source Group's members change.
+	nsSpec.group.SourceGroupName = normalizedUID
+	return &controlplane.NetworkPolicyPeer{AddressGroups: []string{nsSpec.key}}, []*addressGroupSpec{nsSpec}, nil
 }
 
 // svcRefToPeerForCRD creates an Antrea controlplane NetworkPolicyPeer from
@@ -213,76 +310,257 @@ func (n *NetworkPolicyController) svcRefToPeerForCRD(svcRefs []v1alpha1.Namespac
 	return &controlplane.NetworkPolicyPeer{ToServices: controlplaneSvcRefs}
 }
 
-// createAppliedToGroupForInternalGroup creates an AppliedToGroup object corresponding to an
-// internal Group. If the AppliedToGroup already exists, it returns the key
-// otherwise it copies the internal Group contents to an AppliedToGroup resource and returns
-// its key.
-func (n *NetworkPolicyController) createAppliedToGroupForInternalGroup(intGrp *antreatypes.Group) string {
-	key, err := store.GroupKeyFunc(intGrp)
+// appliedToGroupSpec is the AppliedToGroup content a policy worker intends to ensure exists. It is
+// produced by a computeAppliedToGroupFor* helper, which only reads its inputs, and later committed
+// to appliedToGroupStore by commitAppliedToGroup from within the owning policy worker's critical
+// section. Splitting compute from commit this way means a worker decides everything it needs
+// before it takes any lock, so no worker can observe a half-updated store.
+type appliedToGroupSpec struct {
+	key   string
+	group *antreatypes.AppliedToGroup
+}
+
+// computeAppliedToGroupForInternalGroup derives the AppliedToGroup spec for an internal Group,
+// without touching appliedToGroupStore. A source Group can back more than one derived
+// AppliedToGroup (e.g. a per-namespace or per-selector split of the same ClusterGroup); variant
+// distinguishes those from one another by suffixing the source Group's own key. The commit step
+// below looks a spec up by that derived key, not via SourceGroupIndex; SourceGroupName records the
+// source key so that triggerParentGroupSync can find every derived group through SourceGroupIndex.
+// Pass variant "" when there can only ever be one.
+func computeAppliedToGroupForInternalGroup(intGrp *antreatypes.Group, variant string) (*appliedToGroupSpec, error) {
+	sourceKey, err := store.GroupKeyFunc(intGrp)
 	if err != nil {
-		return ""
-	}
-	// Check to see if the AppliedToGroup already exists
-	_, found, _ := n.appliedToGroupStore.Get(key)
-	if found {
-		return key
-	}
-	// Create an AppliedToGroup object for this internal Group.
-	appliedToGroup := &antreatypes.AppliedToGroup{
-		UID:  intGrp.UID,
-		Name: key,
-	}
-	klog.V(2).InfoS("Creating new AppliedToGroup corresponding to internal Group", "AppliedToGroup", appliedToGroup.UID, "internalGroup", intGrp.SourceReference.ToTypedString())
-	n.appliedToGroupStore.Create(appliedToGroup)
-	n.enqueueAppliedToGroup(key)
-	return key
+		return nil, err
+	}
+	key := sourceKey
+	if variant != "" {
+		key = sourceKey + "/" + variant
+	}
+	return &appliedToGroupSpec{
+		key: key,
+		group: &antreatypes.AppliedToGroup{
+			UID:             intGrp.UID,
+			Name:            key,
+			SourceGroupName: sourceKey,
+		},
+	}, nil
 }
 
-// createAppliedToGroupForService creates an AppliedToGroup object corresponding to a Service if it is not created already.
-func (n *NetworkPolicyController) createAppliedToGroupForService(service *v1alpha1.NamespacedName) string {
+// computeAppliedToGroupForService derives the AppliedToGroup spec for a Service AppliedTo, without
+// touching appliedToGroupStore.
+func computeAppliedToGroupForService(service *v1alpha1.NamespacedName) *appliedToGroupSpec {
 	key := getNormalizedUID(k8s.NamespacedName(service.Namespace, service.Name))
-	// Check to see if the AppliedToGroup already exists
-	_, found, _ := n.appliedToGroupStore.Get(key)
-	if found {
-		return key
-	}
-	// Create an AppliedToGroup object for this Service.
-	appliedToGroup := &antreatypes.AppliedToGroup{
-		UID:  types.UID(key),
-		Name: key,
-		Service: &controlplane.ServiceReference{
-			Namespace: service.Namespace,
-			Name:      service.Name,
+	return &appliedToGroupSpec{
+		key: key,
+		group: &antreatypes.AppliedToGroup{
+			UID:  types.UID(key),
+			Name: key,
+			Service: &controlplane.ServiceReference{
+				Namespace: service.Namespace,
+				Name:      service.Name,
+			},
+		},
+	}
+}
+
+// computeAppliedToGroupForServiceAccount derives the AppliedToGroup spec matching all Pods whose
+// ServiceAccount matches saName, without touching appliedToGroupStore. This mirrors the
+// PodSelector synthesized for ServiceAccount peers so that ANPs/ACNPs can also select Pods by
+// ServiceAccount in their AppliedTo field, not just as a peer.
+func computeAppliedToGroupForServiceAccount(namespace, saName string) *appliedToGroupSpec {
+	groupSelector := antreatypes.NewGroupSelector(namespace, serviceAccountNameToPodSelector(saName), nil, nil, nil)
+	key := groupSelector.NormalizedName
+	return &appliedToGroupSpec{
+		key: key,
+		group: &antreatypes.AppliedToGroup{
+			UID:      types.UID(key),
+			Name:     key,
+			Selector: groupSelector,
+		},
+	}
+}
+
+// appliedToPeerToGroupSpec derives the AppliedToGroup spec for a single v1alpha1.NetworkPolicyPeer
+// used in an ANP/ACNP's AppliedTo field, without touching appliedToGroupStore. It is the AppliedTo
+// counterpart of toAntreaPeerForCRD's peer dispatch, routing a ServiceAccount-based AppliedTo entry
+// through computeAppliedToGroupForServiceAccount alongside the pre-existing Group- and
+// selector-based cases, so ANPs/ACNPs can select their AppliedTo Pods by ServiceAccount the same
+// way they can already select peers by one.
+func (n *NetworkPolicyController) appliedToPeerToGroupSpec(appliedTo v1alpha1.NetworkPolicyPeer, defaultNamespace string) (*appliedToGroupSpec, error) {
+	switch {
+	case appliedTo.ServiceAccount != nil:
+		namespace := appliedTo.ServiceAccount.Namespace
+		if namespace == "" {
+			namespace = defaultNamespace
+		}
+		return computeAppliedToGroupForServiceAccount(namespace, appliedTo.ServiceAccount.Name), nil
+	case appliedTo.Group != "":
+		normalizedUID, groupIPBlocks, _ := n.processRefGroupOrClusterGroup(appliedTo.Group, defaultNamespace)
+		if len(groupIPBlocks) > 0 || normalizedUID == "" {
+			return nil, fmt.Errorf("Group %s cannot be used as an AppliedTo: it does not resolve to Pod members", appliedTo.Group)
+		}
+		grpObj, found, _ := n.internalGroupStore.Get(normalizedUID)
+		if !found {
+			return nil, fmt.Errorf("internal Group %s not found", normalizedUID)
+		}
+		return computeAppliedToGroupForInternalGroup(grpObj.(*antreatypes.Group), "")
+	default:
+		groupSelector := antreatypes.NewGroupSelector(defaultNamespace, appliedTo.PodSelector, appliedTo.NamespaceSelector, appliedTo.ExternalEntitySelector, appliedTo.NodeSelector)
+		key := groupSelector.NormalizedName
+		return &appliedToGroupSpec{
+			key: key,
+			group: &antreatypes.AppliedToGroup{
+				UID:      types.UID(key),
+				Name:     key,
+				Selector: groupSelector,
+			},
+		}, nil
+	}
+}
+
+// toAntreaAppliedToGroupsForCRD derives the AppliedToGroup specs for every entry of an ANP/ACNP's
+// AppliedTo field via appliedToPeerToGroupSpec, the AppliedTo counterpart of how toAntreaPeerForCRD
+// above derives AddressGroup specs for a peer list. Like toAntreaPeerForCRD, it only reads
+// internalGroupStore and never touches appliedToGroupStore; the caller must append the returned
+// appliedToGroupSpecs to the rest of the policy's pending specs and stage them all via
+// EnqueuePolicyGroups, so its own worker commits them atomically with the rest of the recomputed
+// internal NetworkPolicy. An entry that fails to resolve is logged and skipped rather than failing
+// the whole NetworkPolicy, matching toAntreaPeerForCRD's handling of an unresolvable peer.
+func (n *NetworkPolicyController) toAntreaAppliedToGroupsForCRD(appliedTo []v1alpha1.NetworkPolicyPeer, np metav1.Object) ([]string, []*appliedToGroupSpec) {
+	var groupNames []string
+	var pendingSpecs []*appliedToGroupSpec
+	for _, at := range appliedTo {
+		spec, err := n.appliedToPeerToGroupSpec(at, np.GetNamespace())
+		if err != nil {
+			klog.Errorf("Antrea NetworkPolicy %s/%s: %v", np.GetNamespace(), np.GetName(), err)
+			continue
+		}
+		groupNames = append(groupNames, spec.key)
+		pendingSpecs = append(pendingSpecs, spec)
+	}
+	return groupNames, pendingSpecs
+}
+
+// commitAppliedToGroup ensures spec's AppliedToGroup exists in appliedToGroupStore, creating it
+// only if this is the first worker to need it, and returns its key. Callers must only invoke this
+// from within the owning policy worker's per-policy critical section, after the whole internal
+// NetworkPolicy has been recomputed, so that no committed internal NetworkPolicy can ever end up
+// referencing a group concurrently removed by another policy's worker; the reference-count update
+// via groupRefCounter happens in the same critical section once every group the policy needs has
+// been committed. As with commitAddressGroup, a spec carrying its own Selector additionally needs it
+// registered with groupingInterface so membership starts being tracked; the direct Get(spec.key)
+// check below guards that registration from running more than once for the same AppliedToGroup.
+func (n *NetworkPolicyController) commitAppliedToGroup(spec *appliedToGroupSpec) string {
+	if _, found, _ := n.appliedToGroupStore.Get(spec.key); found {
+		return spec.key
+	}
+	n.appliedToGroupStore.Create(spec.group)
+	if spec.group.Selector != nil {
+		n.groupingInterface.AddGroup(internalGroupType, spec.key, spec.group.Selector)
+	}
+	klog.V(2).InfoS("Created new AppliedToGroup", "AppliedToGroup", spec.group.UID, "key", spec.key)
+	return spec.key
+}
+
+// addressGroupSpec is the AddressGroup content a policy worker intends to ensure exists; see
+// appliedToGroupSpec for why compute and commit are split.
+type addressGroupSpec struct {
+	key   string
+	group *antreatypes.AddressGroup
+}
+
+// computeAddressGroupForInternalGroup derives the AddressGroup spec for the internal Group of a
+// ClusterGroup/Group, or an error if the Group's key cannot be computed, without touching
+// addressGroupStore. As with computeAppliedToGroupForInternalGroup, a source Group can back more
+// than one derived AddressGroup; variant distinguishes those in the derived key, which is what the
+// commit step looks a spec up by (not SourceGroupIndex). Pass variant "" when there can only ever
+// be one.
+func computeAddressGroupForInternalGroup(intGrp *antreatypes.Group, variant string) (*addressGroupSpec, error) {
+	sourceKey, err := store.GroupKeyFunc(intGrp)
+	if err != nil {
+		return nil, err
+	}
+	key := sourceKey
+	if variant != "" {
+		key = sourceKey + "/" + variant
+	}
+	return &addressGroupSpec{
+		key: key,
+		group: &antreatypes.AddressGroup{
+			UID:             intGrp.UID,
+			Name:            key,
+			SourceGroupName: sourceKey,
+		},
+	}, nil
+}
+
+// computeAddressGroupForSelector derives the AddressGroup spec for a plain selector combination
+// (a PodSelector/NamespaceSelector/ExternalEntitySelector peer, the PodSelector synthesized for a
+// ServiceAccount peer, or a Node peer's NodeSelector), without touching addressGroupStore. Unlike
+// computeAddressGroupForInternalGroup there is no source Group to dedup variants against, so the
+// selector's own NormalizedName is the spec's key, which commitAddressGroup looks the spec up by,
+// and SourceGroupName is left empty.
+func computeAddressGroupForSelector(namespace string, podSelector, namespaceSelector, eeSelector, nodeSelector *metav1.LabelSelector) *addressGroupSpec {
+	groupSelector := antreatypes.NewGroupSelector(namespace, podSelector, namespaceSelector, eeSelector, nodeSelector)
+	key := groupSelector.NormalizedName
+	return &addressGroupSpec{
+		key: key,
+		group: &antreatypes.AddressGroup{
+			UID:      types.UID(getNormalizedUID(key)),
+			Name:     key,
+			Selector: groupSelector,
 		},
 	}
-	klog.V(2).Infof("Creating new AppliedToGroup %v corresponding to a Service %s", appliedToGroup.UID, k8s.NamespacedName(service.Namespace, service.Name))
-	n.appliedToGroupStore.Create(appliedToGroup)
-	n.enqueueAppliedToGroup(key)
-	return key
 }
 
-// createAddressGroupForClusterGroupCRD creates an AddressGroup object corresponding to a
-// ClusterGroup spec. If the AddressGroup already exists, it returns the key
-// otherwise it copies the ClusterGroup CRD contents to an AddressGroup resource and returns
-// its key. If the corresponding internal Group is not found return empty.
-func (n *NetworkPolicyController) createAddressGroupForInternalGroup(intGrp *antreatypes.Group) string {
-	key, err := store.GroupKeyFunc(intGrp)
+// processRefGroupOrClusterGroup resolves a Group/ClusterGroup reference by name to the key of its
+// internal Group's derived AddressGroup, along with any IPBlocks it directly carries (a
+// ClusterGroup may be IPBlock-only, in which case the returned key is empty) and the addressGroupSpec
+// still awaiting commit (nil in the IPBlock-only and not-found cases, where there is nothing to
+// commit). Like computeAddressGroupForInternalGroup, this only reads internalGroupStore; the caller
+// is responsible for staging the returned spec alongside the rest of the policy's pending specs via
+// EnqueuePolicyGroups, so that a concurrent delete of the internal Group this reads from can never
+// race ahead of this policy's own commit.
+func (n *NetworkPolicyController) processRefGroupOrClusterGroup(groupName, namespace string) (string, []controlplane.IPBlock, *addressGroupSpec) {
+	key := k8s.NamespacedName(namespace, groupName)
+	grpObj, found, _ := n.internalGroupStore.Get(key)
+	if !found {
+		klog.V(2).InfoS("Internal Group not found for reference", "group", key)
+		return "", nil, nil
+	}
+	grp := grpObj.(*antreatypes.Group)
+	if len(grp.IPBlocks) > 0 {
+		return "", grp.IPBlocks, nil
+	}
+	spec, err := computeAddressGroupForInternalGroup(grp, "")
 	if err != nil {
-		return ""
+		klog.ErrorS(err, "Failed to compute AddressGroup for internal Group", "group", key)
+		return "", nil, nil
 	}
-	// Check to see if the AddressGroup already exists
-	_, found, _ := n.addressGroupStore.Get(key)
-	if found {
-		return key
+	return spec.key, nil, spec
+}
+
+// commitAddressGroup ensures spec's AddressGroup exists in addressGroupStore, creating it only if
+// this is the first worker to need it, and returns its key. As with commitAppliedToGroup, callers
+// must only invoke this from within the owning policy worker's per-policy critical section. A spec
+// carrying its own Selector additionally needs it registered with groupingInterface so membership
+// starts being tracked; the direct Get(spec.key) check below guards that registration from running
+// more than once for the same AddressGroup. computeAddressGroupForInternalGroup never sets Selector,
+// since that derived AddressGroup mirrors its source Group's membership exactly and relies on the
+// source Group's own registration (from syncInternalGroup) instead; a per-Namespace variant such as
+// toNamespacedGroupPeerForCRD's does set both Selector and SourceGroupName, since it narrows the
+// source Group's selector to one Namespace and so needs its own, distinct membership tracking even
+// though it is still reachable via SourceGroupIndex.
+func (n *NetworkPolicyController) commitAddressGroup(spec *addressGroupSpec) string {
+	if _, found, _ := n.addressGroupStore.Get(spec.key); found {
+		return spec.key
 	}
-	// Create an AddressGroup object for this Cluster Group.
-	addressGroup := &antreatypes.AddressGroup{
-		UID:  intGrp.UID,
-		Name: key,
+	n.addressGroupStore.Create(spec.group)
+	if spec.group.Selector != nil {
+		n.groupingInterface.AddGroup(internalGroupType, spec.key, spec.group.Selector)
 	}
-	n.addressGroupStore.Create(addressGroup)
-	klog.V(2).InfoS("Created new AddressGroup corresponding to internal Group", "AddressGroup", addressGroup.UID, "internalGroup", intGrp.SourceReference.ToTypedString())
-	return key
+	klog.V(2).InfoS("Created new AddressGroup", "AddressGroup", spec.group.UID, "key", spec.key)
+	return spec.key
 }
 
 // getTierPriority retrieves the priority associated with the input Tier name.
@@ -320,8 +598,9 @@ func getNormalizedNameForSelector(sel *antreatypes.GroupSelector) string {
 }
 
 func (n *NetworkPolicyController) syncInternalGroup(key string) error {
-	defer n.triggerANPUpdates(key)
-	defer n.triggerCNPUpdates(key)
+	// triggerParentGroupSync enqueues every AppliedToGroup/AddressGroup derived from this
+	// internal Group, found via store.SourceGroupIndex, since a single internal Group can back
+	// more than one derived group.
 	defer n.triggerParentGroupSync(key)
 	// Retrieve the internal Group corresponding to this key.
 	grpObj, found, _ := n.internalGroupStore.Get(key)
@@ -331,6 +610,16 @@ func (n *NetworkPolicyController) syncInternalGroup(key string) error {
 		return nil
 	}
 	grp := grpObj.(*antreatypes.Group)
+	// Register grp's Selector with n.labelIdentityIndex so that a later label change on a
+	// Pod/Namespace/ExternalEntity resolves, via the label -> selector inverted index, directly
+	// to the AddressGroups/AppliedToGroups derived from this Group instead of re-running every
+	// selector known to the controller. This benefits namespaced and cluster-scope Groups alike,
+	// since both go through this single entry point.
+	if grp.Selector != nil {
+		n.labelIdentityIndex.SetPolicySelectors([]*antreatypes.GroupSelector{grp.Selector}, key)
+	} else {
+		n.labelIdentityIndex.DeletePolicySelectors(key)
+	}
 	if grp.SourceReference.Namespace != "" {
 		// Sync the Group as a Namespaced Group.
 		return n.syncInternalNamespacedGroup(grp)
@@ -0,0 +1,88 @@
+// Copyright 2026 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import "sync"
+
+// groupRefCounter tracks, for every AddressGroup/AppliedToGroup key, the set of policy keys
+// currently referencing it. The per-policy worker that recomputes an internal NetworkPolicy
+// consults it to decide when an AddressGroup/AppliedToGroup is safe to delete: only once no
+// policy's most recently committed internal NetworkPolicy references it any more. This replaces
+// ad hoc "first write wins" deletion, which could race an ACNP add against a concurrent delete
+// that removed a group the add was about to reuse.
+type groupRefCounter struct {
+	mutex sync.Mutex
+	refs  map[string]map[string]struct{} // groupKey -> policyKeys referencing it
+}
+
+func newGroupRefCounter() *groupRefCounter {
+	return &groupRefCounter{refs: map[string]map[string]struct{}{}}
+}
+
+// SetPolicyGroups atomically replaces the set of groups referenced by policyKey with groupKeys
+// and returns the groups that, as a result, are no longer referenced by any policy and are
+// therefore safe for the caller to delete. It must be called from within the per-policy worker's
+// critical section for policyKey so that the replacement is atomic with respect to the commit of
+// policyKey's recomputed internal NetworkPolicy.
+func (c *groupRefCounter) SetPolicyGroups(policyKey string, groupKeys []string) []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	wanted := make(map[string]struct{}, len(groupKeys))
+	for _, key := range groupKeys {
+		wanted[key] = struct{}{}
+		if c.refs[key] == nil {
+			c.refs[key] = map[string]struct{}{}
+		}
+		c.refs[key][policyKey] = struct{}{}
+	}
+	var releasable []string
+	for key, policies := range c.refs {
+		if _, stillWanted := wanted[key]; stillWanted {
+			continue
+		}
+		if _, wasReferenced := policies[policyKey]; !wasReferenced {
+			continue
+		}
+		delete(policies, policyKey)
+		if len(policies) == 0 {
+			delete(c.refs, key)
+			releasable = append(releasable, key)
+		}
+	}
+	return releasable
+}
+
+// DeletePolicy removes every reference held by policyKey, e.g. when the policy itself is deleted,
+// and returns the groups that are now unreferenced and safe to delete.
+func (c *groupRefCounter) DeletePolicy(policyKey string) []string {
+	return c.SetPolicyGroups(policyKey, nil)
+}
+
+// PoliciesReferencing returns the policy keys currently referencing the AddressGroup/AppliedToGroup
+// keyed groupKey, e.g. so the caller can re-enqueue them for recompute after the group's membership
+// changes.
+func (c *groupRefCounter) PoliciesReferencing(groupKey string) []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	policies := c.refs[groupKey]
+	if len(policies) == 0 {
+		return nil
+	}
+	policyKeys := make([]string, 0, len(policies))
+	for policyKey := range policies {
+		policyKeys = append(policyKeys, policyKey)
+	}
+	return policyKeys
+}
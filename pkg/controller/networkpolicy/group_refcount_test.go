@@ -0,0 +1,79 @@
+// Copyright 2026 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupRefCounterSetPolicyGroups(t *testing.T) {
+	c := newGroupRefCounter()
+
+	// policyA starts referencing groupX and groupY.
+	releasable := c.SetPolicyGroups("policyA", []string{"groupX", "groupY"})
+	assert.Empty(t, releasable)
+
+	// policyB also references groupY, so it is not releasable once policyA drops it.
+	releasable = c.SetPolicyGroups("policyB", []string{"groupY"})
+	assert.Empty(t, releasable)
+
+	// policyA recomputes down to just groupX: groupY is still referenced by policyB, and groupX
+	// stays referenced by policyA itself, so nothing is releasable.
+	releasable = c.SetPolicyGroups("policyA", []string{"groupX"})
+	assert.Empty(t, releasable, "groupY is still referenced by policyB and groupX is still referenced by policyA")
+
+	// Dropping policyB's reference to groupY should finally release it, since policyA already
+	// dropped its own reference to groupY in the previous step.
+	releasable = c.SetPolicyGroups("policyB", nil)
+	assert.Equal(t, []string{"groupY"}, releasable)
+
+	// policyA no longer references anything: groupX becomes releasable too.
+	releasable = c.SetPolicyGroups("policyA", nil)
+	assert.Equal(t, []string{"groupX"}, releasable)
+}
+
+func TestGroupRefCounterDeletePolicy(t *testing.T) {
+	c := newGroupRefCounter()
+	c.SetPolicyGroups("policyA", []string{"groupX"})
+	c.SetPolicyGroups("policyB", []string{"groupX"})
+
+	// groupX is still referenced by policyB after policyA is deleted.
+	releasable := c.DeletePolicy("policyA")
+	assert.Empty(t, releasable)
+
+	releasable = c.DeletePolicy("policyB")
+	assert.Equal(t, []string{"groupX"}, releasable)
+}
+
+func TestGroupRefCounterPoliciesReferencing(t *testing.T) {
+	c := newGroupRefCounter()
+	assert.Empty(t, c.PoliciesReferencing("groupX"), "an unreferenced group has no referencing policies")
+
+	c.SetPolicyGroups("policyA", []string{"groupX"})
+	c.SetPolicyGroups("policyB", []string{"groupX", "groupY"})
+
+	policies := c.PoliciesReferencing("groupX")
+	sort.Strings(policies)
+	assert.Equal(t, []string{"policyA", "policyB"}, policies)
+
+	assert.Equal(t, []string{"policyB"}, c.PoliciesReferencing("groupY"))
+
+	c.SetPolicyGroups("policyB", nil)
+	assert.Equal(t, []string{"policyA"}, c.PoliciesReferencing("groupX"))
+	assert.Empty(t, c.PoliciesReferencing("groupY"))
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// labelIdentityAllocator assigns a stable uint32 id to each distinct canonical label identity
+// string, reusing the same id for as long as at least one Pod/Namespace/ExternalEntity carries it.
+type labelIdentityAllocator struct {
+	mutex   sync.Mutex
+	nextID  uint32
+	idsByID map[string]uint32
+}
+
+func newLabelIdentityAllocator() *labelIdentityAllocator {
+	return &labelIdentityAllocator{idsByID: map[string]uint32{}}
+}
+
+func (a *labelIdentityAllocator) idFor(labelIdentity string) uint32 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if id, ok := a.idsByID[labelIdentity]; ok {
+		return id
+	}
+	a.nextID++
+	a.idsByID[labelIdentity] = a.nextID
+	return a.nextID
+}
+
+// canonicalLabelIdentity renders labels as the sorted, comma-separated "k=v,..." string
+// labelidentity.Interface indexes on, so that two members with the same labels always produce the
+// same identity string regardless of map iteration order.
+func canonicalLabelIdentity(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	terms := make([]string, 0, len(keys))
+	for _, k := range keys {
+		terms = append(terms, fmt.Sprintf("%s=%s", k, labels[k]))
+	}
+	return strings.Join(terms, ",")
+}
+
+// onMemberLabelsUpdate updates labelIdentityIndex for a single Pod/Namespace/ExternalEntity whose
+// labels changed from oldLabels to newLabels (either may be nil, for a create or delete), and
+// re-enqueues every AddressGroup whose selector the label change could affect. It is the common
+// body behind onPodUpdate/onNamespaceUpdate/onExternalEntityUpdate, which differ only in how they
+// read labels off their respective object kind.
+func (n *NetworkPolicyController) onMemberLabelsUpdate(oldLabels, newLabels map[string]string) {
+	// A selector the member stops matching is affected as much as one it starts matching, so
+	// candidates for both the old and the new identity are re-enqueued.
+	affected := map[string]struct{}{}
+	if oldIdentity := canonicalLabelIdentity(oldLabels); oldIdentity != "" {
+		for _, selKey := range n.labelIdentityIndex.SelectorKeysForLabelIdentity(oldIdentity) {
+			affected[selKey] = struct{}{}
+		}
+		n.labelIdentityIndex.DeleteLabelIdentity(oldIdentity)
+	}
+	if newIdentity := canonicalLabelIdentity(newLabels); newIdentity != "" {
+		n.labelIdentityIndex.AddLabelIdentity(newIdentity, n.labelIdentityIDs.idFor(newIdentity))
+		for _, selKey := range n.labelIdentityIndex.SelectorKeysForLabelIdentity(newIdentity) {
+			affected[selKey] = struct{}{}
+		}
+	}
+	for selKey := range affected {
+		n.enqueueAddressGroup(selKey)
+	}
+}
+
+// onPodUpdate handles a Pod's labels changing, including creation (oldLabels nil) and deletion
+// (newLabels nil).
+func (n *NetworkPolicyController) onPodUpdate(oldLabels, newLabels map[string]string) {
+	n.onMemberLabelsUpdate(oldLabels, newLabels)
+}
+
+// onNamespaceUpdate handles a Namespace's labels changing.
+func (n *NetworkPolicyController) onNamespaceUpdate(oldLabels, newLabels map[string]string) {
+	n.onMemberLabelsUpdate(oldLabels, newLabels)
+}
+
+// onExternalEntityUpdate handles an ExternalEntity's labels changing.
+func (n *NetworkPolicyController) onExternalEntityUpdate(oldLabels, newLabels map[string]string) {
+	n.onMemberLabelsUpdate(oldLabels, newLabels)
+}
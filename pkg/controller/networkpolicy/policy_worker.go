@@ -0,0 +1,158 @@
+// Copyright 2026 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// policyGroupSpecs is the full set of AppliedToGroup/AddressGroup specs a single internal
+// NetworkPolicy resolves to. It is produced by the compute step (reading, but never mutating,
+// appliedToGroupStore/addressGroupStore/internalGroupStore from within the NetworkPolicy/ANP/ACNP
+// Add or Update handler) and later committed by that policy's own worker.
+type policyGroupSpecs struct {
+	appliedTo []*appliedToGroupSpec
+	addresses []*addressGroupSpec
+}
+
+// keyMutex hands out a *sync.Mutex per key, so unrelated policies' workers never block on one
+// another while still serializing concurrent recomputes of the same policy (e.g. an Update racing
+// a Delete).
+type keyMutex struct {
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newKeyMutex() *keyMutex {
+	return &keyMutex{locks: map[string]*sync.Mutex{}}
+}
+
+func (k *keyMutex) lockFor(key string) *sync.Mutex {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		k.locks[key] = l
+	}
+	return l
+}
+
+// ResetClusterSetPeerReferences forgets every ClusterSet peer reference policyKey previously held,
+// so that the compute step below only needs to record the references it actually resolves this
+// time; it must be called once per recompute, before any peer is resolved via
+// clusterSetPeerIPBlocks, or a reference a previous recompute added would be wiped out again
+// immediately after being re-added.
+func (n *NetworkPolicyController) ResetClusterSetPeerReferences(policyKey string) {
+	n.clusterSetPeerCache.DeletePolicyReferences(policyKey)
+}
+
+// EnqueuePolicyGroups stages the AppliedToGroup/AddressGroup specs the compute step resolved for
+// policyKey and enqueues policyKey for its worker to commit. The NetworkPolicy/ANP/ACNP Add/Update
+// handler calls this once it has finished computing specs (and, for any ClusterSet peer, calling
+// ResetClusterSetPeerReferences followed by toAntreaPeerForCRD); the handler itself never writes to
+// appliedToGroupStore/addressGroupStore, so it can never race a concurrent worker committing a
+// different (older or newer) recompute of the same policy.
+func (n *NetworkPolicyController) EnqueuePolicyGroups(policyKey string, specs *policyGroupSpecs) {
+	n.pendingSpecsMutex.Lock()
+	n.pendingSpecs[policyKey] = specs
+	n.pendingSpecsMutex.Unlock()
+	n.queue.Add(policyKey)
+}
+
+// EnqueuePolicyDeletion enqueues policyKey with no pending specs, so its worker releases every
+// group it held instead of committing a new set.
+func (n *NetworkPolicyController) EnqueuePolicyDeletion(policyKey string) {
+	n.pendingSpecsMutex.Lock()
+	delete(n.pendingSpecs, policyKey)
+	n.pendingSpecsMutex.Unlock()
+	n.queue.Add(policyKey)
+}
+
+// policyWorker runs commitPolicyGroups for as long as there are internal NetworkPolicy keys to
+// process. Run starts workerCount of them, each as its own goroutine.
+func (n *NetworkPolicyController) policyWorker() {
+	for n.processNextPolicyWorkItem() {
+	}
+}
+
+func (n *NetworkPolicyController) processNextPolicyWorkItem() bool {
+	key, quit := n.queue.Get()
+	if quit {
+		return false
+	}
+	defer n.queue.Done(key)
+
+	policyKey := key.(string)
+	if err := n.commitPolicyGroups(policyKey); err != nil {
+		klog.ErrorS(err, "Failed to commit internal NetworkPolicy groups, requeuing", "policy", policyKey)
+		n.queue.AddRateLimited(key)
+		return true
+	}
+	n.queue.Forget(key)
+	return true
+}
+
+// commitPolicyGroups runs under policyKey's own lock, so that committing every AppliedToGroup/
+// AddressGroup a policy's latest recompute needs, and releasing the ones it no longer needs, is
+// atomic with respect to any other worker processing the same policy key. A group released here is
+// only actually deleted once groupRefCounter confirms no other policy still references it, so a
+// concurrent Add of a different policy that is about to start sharing this group can never lose the
+// race against this policy's delete.
+func (n *NetworkPolicyController) commitPolicyGroups(policyKey string) error {
+	lock := n.policyLocks.lockFor(policyKey)
+	lock.Lock()
+	defer lock.Unlock()
+
+	n.pendingSpecsMutex.Lock()
+	specs, found := n.pendingSpecs[policyKey]
+	delete(n.pendingSpecs, policyKey)
+	n.pendingSpecsMutex.Unlock()
+
+	var groupKeys []string
+	if found {
+		for _, spec := range specs.appliedTo {
+			groupKeys = append(groupKeys, n.commitAppliedToGroup(spec))
+		}
+		for _, spec := range specs.addresses {
+			groupKeys = append(groupKeys, n.commitAddressGroup(spec))
+		}
+	}
+	// groupKeys is nil when the policy was deleted (or its Add/Update handler never staged
+	// specs before this worker ran), in which case SetPolicyGroups releases every group
+	// policyKey previously held.
+	for _, groupKey := range n.groupRefCounter.SetPolicyGroups(policyKey, groupKeys) {
+		n.deleteReleasedGroup(groupKey)
+	}
+	return nil
+}
+
+// deleteReleasedGroup deletes an AppliedToGroup or AddressGroup, whichever store it is found in,
+// once groupRefCounter has confirmed no policy references it any more.
+func (n *NetworkPolicyController) deleteReleasedGroup(groupKey string) {
+	if _, found, _ := n.appliedToGroupStore.Get(groupKey); found {
+		if err := n.appliedToGroupStore.Delete(groupKey); err != nil {
+			klog.ErrorS(err, "Failed to delete unreferenced AppliedToGroup", "key", groupKey)
+		}
+		return
+	}
+	if _, found, _ := n.addressGroupStore.Get(groupKey); found {
+		if err := n.addressGroupStore.Delete(groupKey); err != nil {
+			klog.ErrorS(err, "Failed to delete unreferenced AddressGroup", "key", groupKey)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package store holds the in-memory stores the NetworkPolicy controller keeps its internal
+// Groups, AppliedToGroups and AddressGroups in.
+package store
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/cache"
+
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+// SourceGroupIndex indexes AppliedToGroup/AddressGroup objects by the key of the internal Group they
+// were derived from. A single source can back more than one derived group (for example, a Group
+// peer combined with a Namespaces field is split into one AddressGroup per matching Namespace); this
+// index lets every derived group for a given source be found without a full store scan, e.g. to
+// enqueue them all for resync. Groups derived from a Service, a ServiceAccount or a plain selector
+// carry no source key and are not in the index.
+const SourceGroupIndex = "sourceGroup"
+
+// GroupKeyFunc returns an internal Group's own store key.
+func GroupKeyFunc(obj interface{}) (string, error) {
+	grp, ok := obj.(*antreatypes.Group)
+	if !ok {
+		return "", fmt.Errorf("object %v is not an internal Group", obj)
+	}
+	return string(grp.UID), nil
+}
+
+// appliedToGroupKeyFunc returns an AppliedToGroup's own store key, its Name.
+func appliedToGroupKeyFunc(obj interface{}) (string, error) {
+	group, ok := obj.(*antreatypes.AppliedToGroup)
+	if !ok {
+		return "", fmt.Errorf("object %v is not an AppliedToGroup", obj)
+	}
+	return group.Name, nil
+}
+
+// addressGroupKeyFunc returns an AddressGroup's own store key, its Name.
+func addressGroupKeyFunc(obj interface{}) (string, error) {
+	group, ok := obj.(*antreatypes.AddressGroup)
+	if !ok {
+		return "", fmt.Errorf("object %v is not an AddressGroup", obj)
+	}
+	return group.Name, nil
+}
+
+// sourceGroupIndexFunc is the cache.IndexFunc backing SourceGroupIndex for both AppliedToGroups and
+// AddressGroups. A group with no SourceGroupName (e.g. one derived straight from a plain selector
+// peer, with nothing to dedup against) is simply omitted from the index.
+func sourceGroupIndexFunc(obj interface{}) ([]string, error) {
+	switch group := obj.(type) {
+	case *antreatypes.AppliedToGroup:
+		if group.SourceGroupName == "" {
+			return nil, nil
+		}
+		return []string{group.SourceGroupName}, nil
+	case *antreatypes.AddressGroup:
+		if group.SourceGroupName == "" {
+			return nil, nil
+		}
+		return []string{group.SourceGroupName}, nil
+	default:
+		return nil, fmt.Errorf("object %v does not support %s indexing", obj, SourceGroupIndex)
+	}
+}
+
+// Interface is the minimal store API the NetworkPolicy controller needs from an AppliedToGroup or
+// AddressGroup cache: look an object up by its own key, create it, or list every object sharing an
+// index value (used with SourceGroupIndex to find every group derived from one source).
+type Interface interface {
+	Get(key string) (interface{}, bool, error)
+	Create(obj interface{}) error
+	Delete(key string) error
+	ByIndex(indexName, indexValue string) ([]interface{}, error)
+}
+
+// indexer adapts a cache.Indexer, whose Get takes an object rather than a key, to Interface.
+type indexer struct {
+	cache.Indexer
+}
+
+func (s *indexer) Get(key string) (interface{}, bool, error) {
+	return s.GetByKey(key)
+}
+
+func (s *indexer) Create(obj interface{}) error {
+	return s.Indexer.Add(obj)
+}
+
+func (s *indexer) Delete(key string) error {
+	obj, found, err := s.GetByKey(key)
+	if err != nil || !found {
+		return err
+	}
+	return s.Indexer.Delete(obj)
+}
+
+// NewAppliedToGroupStore returns an AppliedToGroup store with SourceGroupIndex registered.
+func NewAppliedToGroupStore() Interface {
+	return &indexer{cache.NewIndexer(appliedToGroupKeyFunc, cache.Indexers{SourceGroupIndex: sourceGroupIndexFunc})}
+}
+
+// NewAddressGroupStore returns an AddressGroup store with SourceGroupIndex registered.
+func NewAddressGroupStore() Interface {
+	return &indexer{cache.NewIndexer(addressGroupKeyFunc, cache.Indexers{SourceGroupIndex: sourceGroupIndexFunc})}
+}
+
+// NewGroupStore returns an internal Group store keyed by GroupKeyFunc.
+func NewGroupStore() Interface {
+	return &indexer{cache.NewIndexer(GroupKeyFunc, cache.Indexers{})}
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package types holds the controller-internal representations that back the NetworkPolicy
+// controller's stores: Group/AppliedToGroup/AddressGroup, and the GroupSelector they are all keyed
+// by, independent of whichever CRD (ClusterGroup, Group, ANP, ACNP) originated them.
+package types
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+)
+
+// GroupSelector describes how a Group's members (Pods, Namespaces or ExternalEntities) are
+// selected. NormalizedName is a canonical, order-independent string representation of the other
+// fields, computed once by NewGroupSelector, so that two selectors with the same effective meaning
+// always produce the same store key.
+type GroupSelector struct {
+	Namespace              string
+	PodSelector            *metav1.LabelSelector
+	NamespaceSelector      *metav1.LabelSelector
+	ExternalEntitySelector *metav1.LabelSelector
+	NodeSelector           *metav1.LabelSelector
+	NormalizedName         string
+}
+
+// NewGroupSelector creates a GroupSelector and computes its NormalizedName.
+func NewGroupSelector(namespace string, podSelector, namespaceSelector, eeSelector, nodeSelector *metav1.LabelSelector) *GroupSelector {
+	groupSelector := &GroupSelector{
+		Namespace:              namespace,
+		PodSelector:            podSelector,
+		NamespaceSelector:      namespaceSelector,
+		ExternalEntitySelector: eeSelector,
+		NodeSelector:           nodeSelector,
+	}
+	name := generateNormalizedName(namespace, podSelector, namespaceSelector, eeSelector, nodeSelector)
+	groupSelector.NormalizedName = name
+	return groupSelector
+}
+
+// generateNormalizedName produces a canonical string for a given selector combination. It is
+// deliberately a plain, sorted-key serialization (rather than a hash) so that store keys remain
+// human-readable in logs and `kubectl get addressgroups`.
+func generateNormalizedName(namespace string, podSelector, namespaceSelector, eeSelector, nodeSelector *metav1.LabelSelector) string {
+	normalized := fmt.Sprintf("namespace=%s", namespace)
+	if podSelector != nil {
+		normalized += fmt.Sprintf(",podSelector=%s", metav1.FormatLabelSelector(podSelector))
+	}
+	if namespaceSelector != nil {
+		normalized += fmt.Sprintf(",namespaceSelector=%s", metav1.FormatLabelSelector(namespaceSelector))
+	}
+	if eeSelector != nil {
+		normalized += fmt.Sprintf(",externalEntitySelector=%s", metav1.FormatLabelSelector(eeSelector))
+	}
+	if nodeSelector != nil {
+		normalized += fmt.Sprintf(",nodeSelector=%s", metav1.FormatLabelSelector(nodeSelector))
+	}
+	return normalized
+}
+
+// GroupSourceReference identifies the CR (ClusterGroup, Group, or an ANP/ACNP's own
+// AppliedTo/peer) that an internal Group was created for, for logging and status reporting.
+type GroupSourceReference struct {
+	Namespace string
+	Name      string
+}
+
+// Group describes a set of addressable entities (Pods, ExternalEntities, IPBlocks or child Groups)
+// selected on behalf of a ClusterGroup/Group CR. It is the input computeAppliedToGroupForInternalGroup/
+// computeAddressGroupForInternalGroup derive an AppliedToGroup/AddressGroup from.
+type Group struct {
+	UID             types.UID
+	SourceReference *GroupSourceReference
+	Selector        *GroupSelector
+	IPBlocks        []controlplane.IPBlock
+	ChildGroups     []string
+}
+
+// AppliedToGroup describes a set of Pods/ExternalEntities that a NetworkPolicy applies to.
+// SourceGroupName is the key of the internal Group this AppliedToGroup was derived from, if any
+// (empty for a Service, ServiceAccount or plain selector AppliedToGroup); it is what
+// store.SourceGroupIndex indexes on, letting one source back
+// more than one derived AppliedToGroup (for example, one per Namespace).
+type AppliedToGroup struct {
+	UID             types.UID
+	Name            string
+	Selector        *GroupSelector
+	Service         *controlplane.ServiceReference
+	SourceGroupName string
+}
+
+// AddressGroup describes a set of addressable entities used as a NetworkPolicyPeer.
+// SourceGroupName plays the same role as it does for AppliedToGroup.
+type AddressGroup struct {
+	UID             types.UID
+	Name            string
+	Selector        *GroupSelector
+	SourceGroupName string
+}